@@ -0,0 +1,144 @@
+// Package muxtrace provides tracing for the Gorilla Web Toolkit's mux
+// router (https://github.com/gorilla/mux).
+package muxtrace
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DataDog/dd-trace-go/tracer"
+	"github.com/gorilla/mux"
+)
+
+// MuxTracer wraps a gorilla/mux router so that every matched route is
+// traced as a mux.request span. Construct one with NewMuxTracer, then
+// either register routes through HandleFunc or wrap a bare
+// http.HandlerFunc with TraceHandleFunc.
+type MuxTracer struct {
+	service          string
+	tracer           *tracer.Tracer
+	propagator       tracer.Propagator
+	websocketTracing bool
+	samplingRules    []SamplingRule
+}
+
+// NewMuxTracer creates a MuxTracer that submits spans to trc under
+// service. Incoming requests are checked for distributed trace context
+// using B3 multi-header, B3 single-header and W3C traceparent headers, in
+// that order; use SetPropagator to change this.
+func NewMuxTracer(service string, trc *tracer.Tracer) *MuxTracer {
+	return &MuxTracer{
+		service: service,
+		tracer:  trc,
+		propagator: tracer.NewChainedPropagator(
+			tracer.NewB3Propagator(),
+			tracer.NewB3SingleHeaderPropagator(),
+			tracer.NewW3CPropagator(),
+		),
+	}
+}
+
+// SetPropagator overrides the Propagator used to extract distributed
+// trace context from incoming requests.
+func (mt *MuxTracer) SetPropagator(p tracer.Propagator) {
+	mt.propagator = p
+}
+
+// SetSamplingRules installs the rules used to assign a sampling priority
+// to each span once the request has been handled. Rules are evaluated in
+// order and the first match wins, so a default rule (one with every
+// field left as its zero value) should come last. Spans of hijacked
+// connections kept open by websocket tracing are not matched against the
+// rules.
+func (mt *MuxTracer) SetSamplingRules(rules []SamplingRule) {
+	mt.samplingRules = rules
+}
+
+// HandleFunc wraps handler in TraceHandleFunc and registers it on router
+// for pattern, returning the *mux.Route exactly as router.HandleFunc
+// would, so callers can keep chaining mux options (Methods, Name, ...).
+func (mt *MuxTracer) HandleFunc(router *mux.Router, pattern string, handler http.HandlerFunc) *mux.Route {
+	return router.HandleFunc(pattern, mt.TraceHandleFunc(handler))
+}
+
+// TraceHandleFunc wraps handler so that it runs inside a mux.request
+// span. If the incoming request carries distributed trace context, the
+// span is created as a child of that remote trace instead of a new one.
+func (mt *MuxTracer) TraceHandleFunc(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !mt.tracer.Enabled() {
+			handler(w, r)
+			return
+		}
+		span := mt.tracer.NewRootSpan("mux.request", mt.service, "")
+		if mt.propagator != nil {
+			if sc, err := mt.propagator.Extract(tracer.TextMapCarrier(r.Header)); err == nil && sc != nil {
+				span.TraceID = sc.TraceID
+				span.ParentID = sc.SpanID
+				if sc.Sampled {
+					span.SetSamplingPriority(sc.Priority)
+				}
+			}
+		}
+		routeName, pathTemplate := routeInfo(r)
+		span.Resource = r.Method + " " + pathTemplate
+		span.SetMeta("http.method", r.Method)
+		span.SetMeta("http.url", r.URL.Path)
+
+		traceRW := &tracedResponseWriter{ResponseWriter: w, mt: mt, span: span}
+		ctx := tracer.ContextWithSpan(r.Context(), span)
+		handler(traceRW, r.WithContext(ctx))
+
+		// A hijacked connection (typically a websocket upgrade) outlives
+		// ServeHTTP and is handed off to wsTracedConn, which tags and
+		// finishes the span itself from its read/write pump goroutines
+		// (see Hijack). Past this handoff point nothing in this goroutine
+		// may touch the span, since that would race with those goroutines.
+		if traceRW.hijacked && mt.websocketTracing {
+			return
+		}
+
+		status := traceRW.status()
+		span.SetMeta("http.status_code", strconv.Itoa(status))
+		if status/100 == 5 {
+			span.SetError(fmt.Errorf("%d: %s", status, http.StatusText(status)))
+		}
+
+		if rule, ok := matchSamplingRule(mt.samplingRules, routeName, r.Method, pathTemplate, status); ok {
+			span.SetSamplingPriority(rule.Priority)
+		}
+
+		span.Finish()
+	}
+}
+
+// tracedResponseWriter records the status code written by the wrapped
+// handler so it can be attached to the span once ServeHTTP returns, and
+// optionally wraps a hijacked connection for per-frame websocket tracing.
+type tracedResponseWriter struct {
+	http.ResponseWriter
+	mt       *MuxTracer
+	span     *tracer.Span
+	code     int
+	hijacked bool
+}
+
+func (w *tracedResponseWriter) WriteHeader(status int) {
+	w.code = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *tracedResponseWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *tracedResponseWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
@@ -184,8 +184,119 @@ func TestMuxWebsocket(t *testing.T) {
 	assert.Equal(s.Error, int32(0))
 }
 
+func TestMuxWebsocketFrameTracing(t *testing.T) {
+	assert := assert.New(t)
+
+	trc, transport, mt := getTestTracer("my-service")
+	mt.WithWebsocketTracing(true)
+	router := mux.NewRouter()
+	router.HandleFunc("/ws-frames", mt.TraceHandleFunc(handlerWsFrame(t)))
+
+	url := "/ws-frames"
+	req := httptest.NewRequest("GET", url, nil)
+	writer := &hijackableResponseRecorder{httptest.NewRecorder()}
+	router.ServeHTTP(writer, req)
+
+	// ensure the parent span and one frame span were both traced.
+	assert.Nil(trc.FlushTraces())
+	traces := transport.Traces()
+	assert.Len(traces, 1)
+	spans := traces[0]
+	assert.Len(spans, 2)
+
+	var parent, frame *tracer.Span
+	for _, s := range spans {
+		switch s.Name {
+		case "mux.request":
+			parent = s
+		case "mux.websocket.frame":
+			frame = s
+		}
+	}
+	if assert.NotNil(parent) {
+		assert.Equal(parent.Resource, "GET "+url)
+	}
+	if assert.NotNil(frame) {
+		assert.Equal(frame.GetMeta("websocket.direction"), "write")
+		assert.Equal(frame.GetMeta("websocket.opcode"), "text")
+		assert.Equal(frame.GetMeta("websocket.payload_size"), "2")
+	}
+}
+
+func TestMuxTracerRouteTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	tracer, transport, mt := getTestTracer("my-service")
+	router := mux.NewRouter()
+	mt.HandleFunc(router, "/users/{id}", handler200(t)).Name("get-user")
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, req)
+	assert.Equal(writer.Code, 200)
+
+	assert.Nil(tracer.FlushTraces())
+	spans := transport.Traces()[0]
+	assert.Len(spans, 1)
+	assert.Equal(spans[0].Resource, "GET /users/{id}")
+}
+
+func TestMuxTracerSamplingRules(t *testing.T) {
+	assert := assert.New(t)
+
+	tracer, transport, mt := getTestTracer("my-service")
+	mt.SetSamplingRules([]SamplingRule{
+		{PathTemplate: "/healthz", Priority: 0},
+		{StatusClass: "5xx", Priority: 2},
+		{Priority: 1},
+	})
+	router := mux.NewRouter()
+	mt.HandleFunc(router, "/healthz", handler200(t))
+	mt.HandleFunc(router, "/500", handler500(t))
+	mt.HandleFunc(router, "/200", handler200(t))
+
+	for url := range map[string]int{"/healthz": 0, "/500": 2, "/200": 1} {
+		req := httptest.NewRequest("GET", url, nil)
+		writer := httptest.NewRecorder()
+		router.ServeHTTP(writer, req)
+	}
+
+	assert.Nil(tracer.FlushTraces())
+	traces := transport.Traces()
+	assert.Len(traces, 3)
+	for _, spans := range traces {
+		s := spans[0]
+		switch s.Resource {
+		case "GET /healthz":
+			assert.Equal(s.GetSamplingPriority(), 0)
+		case "GET /500":
+			assert.Equal(s.GetSamplingPriority(), 2)
+		case "GET /200":
+			assert.Equal(s.GetSamplingPriority(), 1)
+		}
+	}
+}
+
 // test handlers
 
+// handlerWsFrame hijacks the connection directly (bypassing any real
+// websocket handshake) and writes a single unmasked text frame, to
+// exercise frame-level tracing without depending on gorilla/websocket's
+// internal buffering.
+func handlerWsFrame(t *testing.T) http.HandlerFunc {
+	assert := assert.New(t)
+	return func(w http.ResponseWriter, r *http.Request) {
+		hj, ok := w.(http.Hijacker)
+		assert.True(ok)
+		conn, _, err := hj.Hijack()
+		assert.NoError(err)
+		// FIN=1, opcode=text, unmasked, 2-byte payload "hi".
+		_, err = conn.Write([]byte{0x81, 0x02, 'h', 'i'})
+		assert.NoError(err)
+		assert.NoError(conn.Close())
+	}
+}
+
 func handler200(t *testing.T) http.HandlerFunc {
 	assert := assert.New(t)
 	return func(w http.ResponseWriter, r *http.Request) {
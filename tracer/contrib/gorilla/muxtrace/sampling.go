@@ -0,0 +1,71 @@
+package muxtrace
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// SamplingRule assigns a sampling priority to spans whose route matches
+// every non-empty field. RouteName and PathTemplate are glob patterns (as
+// understood by path.Match: *, ?, and [...] classes); Method and
+// StatusClass ("2xx", "4xx", "5xx", ...) are matched case-insensitively.
+// A field left at its zero value matches anything, so a rule with every
+// field empty acts as a default.
+type SamplingRule struct {
+	RouteName    string
+	Method       string
+	PathTemplate string
+	StatusClass  string
+	Priority     int
+}
+
+// matchSamplingRule returns the first rule in rules whose fields all
+// match the given route name, method, path template and HTTP status.
+func matchSamplingRule(rules []SamplingRule, routeName, method, pathTemplate string, status int) (SamplingRule, bool) {
+	statusClass := fmt.Sprintf("%dxx", status/100)
+	for _, rule := range rules {
+		if rule.RouteName != "" && !globMatch(rule.RouteName, routeName) {
+			continue
+		}
+		if rule.Method != "" && !strings.EqualFold(rule.Method, method) {
+			continue
+		}
+		if rule.PathTemplate != "" && !globMatch(rule.PathTemplate, pathTemplate) {
+			continue
+		}
+		if rule.StatusClass != "" && !strings.EqualFold(rule.StatusClass, statusClass) {
+			continue
+		}
+		return rule, true
+	}
+	return SamplingRule{}, false
+}
+
+// globMatch reports whether name matches pattern, treating a match error
+// (an invalid pattern) as no match rather than panicking on a bad rule.
+func globMatch(pattern, name string) bool {
+	ok, err := path.Match(pattern, name)
+	return err == nil && ok
+}
+
+// routeInfo returns the matched route's name and path template for r, so
+// resources stay low-cardinality ("GET /users/{id}" instead of
+// "GET /users/42") and sampling rules can key off either. If r wasn't
+// matched to a named path template - no route, or an unnamed one with no
+// template - the raw request path is used as the template and the route
+// name is left empty.
+func routeInfo(r *http.Request) (routeName, pathTemplate string) {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "", r.URL.Path
+	}
+	routeName = route.GetName()
+	if tmpl, err := route.GetPathTemplate(); err == nil && tmpl != "" {
+		return routeName, tmpl
+	}
+	return routeName, r.URL.Path
+}
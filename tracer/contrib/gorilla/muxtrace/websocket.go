@@ -0,0 +1,199 @@
+package muxtrace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/DataDog/dd-trace-go/tracer"
+)
+
+// Websocket opcodes, as defined by RFC 6455 section 11.8.
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// WithWebsocketTracing opts a MuxTracer into per-frame websocket tracing:
+// when a handler hijacks the connection (directly, or via an upgrade
+// library such as gorilla/websocket), the mux.request span is kept open
+// for the life of the connection instead of finishing when ServeHTTP
+// returns, and a mux.websocket.frame child span is emitted for every
+// frame read from or written to it.
+func (mt *MuxTracer) WithWebsocketTracing(enabled bool) *MuxTracer {
+	mt.websocketTracing = enabled
+	return mt
+}
+
+// Hijack implements http.Hijacker so upgrade libraries can still take over
+// the connection. When websocket tracing is enabled, the returned conn is
+// wrapped so subsequent frames are traced and the parent span is finished
+// when the connection is closed; otherwise Hijack behaves exactly as the
+// wrapped ResponseWriter's.
+//
+// The parent span is tagged with the handshake's status code here, before
+// handing the conn off, rather than back in TraceHandleFunc: once the
+// conn is handed off, the parent may be finished by it at any time from
+// another goroutine, so this is the last point at which tagging it from
+// ServeHTTP's goroutine is safe.
+func (w *tracedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	w.hijacked = true
+	if !w.mt.websocketTracing {
+		return conn, rw, nil
+	}
+	if w.code == 0 {
+		w.code = http.StatusSwitchingProtocols
+	}
+	w.span.SetMeta("http.status_code", strconv.Itoa(w.code))
+	return &wsTracedConn{Conn: conn, tracer: w.mt.tracer, parent: w.span}, rw, nil
+}
+
+// wsTracedConn wraps a hijacked net.Conn so each websocket frame read
+// from or written to it emits a mux.websocket.frame span, and so the
+// parent mux.request span finishes when the connection closes.
+//
+// Frame boundaries are detected on a best-effort basis: each Read/Write
+// call is assumed to carry exactly one frame, which holds for the common
+// case of gorilla/websocket's buffered reader/writer but is not
+// guaranteed by the TCP byte stream in general. If a call's bytes don't
+// decode as exactly one complete frame (short, partial, or coalescing
+// more than one), traceFrame skips it rather than emit a payload_size
+// that doesn't correspond to a real frame.
+//
+// Read and Write run concurrently on a real connection (one per pump
+// goroutine) and Close may be called from yet another, all against the
+// same parent span; mu serializes every touch of it so NewChildSpan and
+// Finish never race.
+type wsTracedConn struct {
+	net.Conn
+	tracer *tracer.Tracer
+	parent *tracer.Span
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *wsTracedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.traceFrame("read", b[:n])
+	}
+	return n, err
+}
+
+func (c *wsTracedConn) Write(b []byte) (int, error) {
+	c.traceFrame("write", b)
+	return c.Conn.Write(b)
+}
+
+func (c *wsTracedConn) Close() error {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		c.parent.Finish()
+	}
+	c.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// traceFrame parses a single websocket frame out of b and emits a child
+// span describing it. Anything that isn't exactly one complete frame
+// (too short, or the header's declared length doesn't match len(b)) is
+// silently skipped rather than reported as a guess.
+func (c *wsTracedConn) traceFrame(direction string, b []byte) {
+	opcode, payloadLen, closeCode, ok := parseFrameHeader(b)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	span := c.tracer.NewChildSpan("mux.websocket.frame", c.parent)
+	span.SetMeta("websocket.direction", direction)
+	span.SetMeta("websocket.opcode", opcodeName(opcode))
+	span.SetMeta("websocket.payload_size", strconv.Itoa(payloadLen))
+	if opcode == wsOpClose && closeCode != 0 {
+		span.SetMeta("websocket.close_code", strconv.Itoa(int(closeCode)))
+	}
+	span.Finish()
+}
+
+// parseFrameHeader decodes the opcode, payload length and (for close
+// frames) close code from a websocket frame per RFC 6455. ok is false
+// unless b holds exactly one complete frame: too short to contain a
+// header, or a header whose declared total length doesn't match len(b),
+// are both rejected so callers never tag a guess as a real frame.
+func parseFrameHeader(b []byte) (opcode byte, payloadLen int, closeCode uint16, ok bool) {
+	if len(b) < 2 {
+		return 0, 0, 0, false
+	}
+	opcode = b[0] & 0x0f
+	masked := b[1]&0x80 != 0
+	length := int(b[1] & 0x7f)
+	offset := 2
+	switch length {
+	case 126:
+		if len(b) < offset+2 {
+			return 0, 0, 0, false
+		}
+		length = int(binary.BigEndian.Uint16(b[offset:]))
+		offset += 2
+	case 127:
+		if len(b) < offset+8 {
+			return 0, 0, 0, false
+		}
+		length = int(binary.BigEndian.Uint64(b[offset:]))
+		offset += 8
+	}
+	if masked {
+		offset += 4
+	}
+	if opcode == wsOpClose && len(b) >= offset+2 {
+		hi, lo := b[offset], b[offset+1]
+		if masked {
+			hi, lo = hi^b[offset-4], lo^b[offset-3]
+		}
+		closeCode = uint16(hi)<<8 | uint16(lo)
+	}
+	if offset+length != len(b) {
+		return 0, 0, 0, false
+	}
+	return opcode, length, closeCode, true
+}
+
+func opcodeName(opcode byte) string {
+	switch opcode {
+	case wsOpContinuation:
+		return "continuation"
+	case wsOpText:
+		return "text"
+	case wsOpBinary:
+		return "binary"
+	case wsOpClose:
+		return "close"
+	case wsOpPing:
+		return "ping"
+	case wsOpPong:
+		return "pong"
+	default:
+		return "unknown"
+	}
+}
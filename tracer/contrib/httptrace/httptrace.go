@@ -0,0 +1,63 @@
+// Package httptrace provides helpers for tracing outbound HTTP calls and
+// propagating distributed trace context to the services they call.
+package httptrace
+
+import (
+	"net/http"
+
+	"github.com/DataDog/dd-trace-go/tracer"
+)
+
+// WrapClient returns an *http.Client that behaves like c, except that
+// every request whose context carries a span has distributed trace
+// context injected via propagator, as a child of that span so the
+// downstream service nests under the caller's trace. Requests whose
+// context carries no span are sent without trace headers.
+func WrapClient(c *http.Client, trc *tracer.Tracer, propagator tracer.Propagator) *http.Client {
+	if c == nil {
+		c = &http.Client{}
+	}
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	traced := *c
+	traced.Transport = &roundTripper{base: base, tracer: trc, propagator: propagator}
+	return &traced
+}
+
+// roundTripper injects distributed trace context into every request it
+// forwards to the wrapped http.RoundTripper.
+type roundTripper struct {
+	base       http.RoundTripper
+	tracer     *tracer.Tracer
+	propagator tracer.Propagator
+}
+
+// RoundTrip injects trace context derived from req's span (if any) and
+// delegates to the wrapped RoundTripper.
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.propagator != nil {
+		if span, ok := tracer.SpanFromContext(req.Context()); ok {
+			sc := &tracer.SpanContext{
+				TraceID:  span.TraceID,
+				SpanID:   span.SpanID,
+				Sampled:  true,
+				Priority: span.GetSamplingPriority(),
+			}
+			req = cloneRequestWithHeaders(req)
+			rt.propagator.Inject(sc, tracer.TextMapCarrier(req.Header))
+		}
+	}
+	return rt.base.RoundTrip(req)
+}
+
+// cloneRequestWithHeaders clones req so injecting trace headers doesn't
+// mutate the caller's original *http.Request.
+func cloneRequestWithHeaders(req *http.Request) *http.Request {
+	clone := req.Clone(req.Context())
+	if clone.Header == nil {
+		clone.Header = make(http.Header)
+	}
+	return clone
+}
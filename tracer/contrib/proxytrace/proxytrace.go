@@ -0,0 +1,186 @@
+// Package proxytrace provides tracing for net/http/httputil.ReverseProxy,
+// emitting a client-side http.proxy span for every request a proxy
+// forwards to a backend.
+package proxytrace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/DataDog/dd-trace-go/tracer"
+)
+
+// ServiceResolver picks a span's service name from the backend a request
+// is routed to, so a proxy fronting several backends gets one service
+// name per backend instead of a single catch-all name.
+type ServiceResolver func(backend *url.URL) string
+
+// ProxyTracer wraps a *httputil.ReverseProxy so that every request it
+// forwards is traced. Construct one with NewProxyTracer, then call Trace
+// on the proxy you want instrumented.
+type ProxyTracer struct {
+	service    string
+	tracer     *tracer.Tracer
+	propagator tracer.Propagator
+	resolver   ServiceResolver
+}
+
+// NewProxyTracer creates a ProxyTracer that submits spans to trc under
+// service. Use SetServiceResolver to name services after the resolved
+// backend instead, and SetPropagator to change how trace context is
+// injected into the forwarded request.
+func NewProxyTracer(service string, trc *tracer.Tracer) *ProxyTracer {
+	return &ProxyTracer{
+		service: service,
+		tracer:  trc,
+		propagator: tracer.NewChainedPropagator(
+			tracer.NewB3Propagator(),
+			tracer.NewB3SingleHeaderPropagator(),
+			tracer.NewW3CPropagator(),
+		),
+	}
+}
+
+// SetPropagator overrides the Propagator used to inject distributed trace
+// context into the forwarded request.
+func (pt *ProxyTracer) SetPropagator(p tracer.Propagator) {
+	pt.propagator = p
+}
+
+// SetServiceResolver names each span after the backend a request was
+// routed to, instead of the ProxyTracer's default service.
+func (pt *ProxyTracer) SetServiceResolver(resolver ServiceResolver) {
+	pt.resolver = resolver
+}
+
+// proxyState tracks the span for one proxied request plus the number of
+// times its Transport was asked to round-trip it, so a retried request
+// can be tagged with how many attempts it took. finishOnce guards against
+// tagging and finishing the span twice, since ReverseProxy calls
+// ErrorHandler when a user-supplied ModifyResponse itself returns an
+// error, on top of calling it for a failed round trip.
+type proxyState struct {
+	span       *tracer.Span
+	attempts   int32
+	finishOnce sync.Once
+}
+
+type proxyStateKey struct{}
+
+// Trace instruments proxy in place and returns it: Director starts a
+// http.proxy span and injects distributed trace headers into the
+// forwarded request, Transport counts round-trip attempts, and
+// ModifyResponse/ErrorHandler tag and finish the span with the outcome.
+func (pt *ProxyTracer) Trace(proxy *httputil.ReverseProxy) *httputil.ReverseProxy {
+	director := proxy.Director
+	modifyResponse := proxy.ModifyResponse
+	errorHandler := proxy.ErrorHandler
+	transport := proxy.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	proxy.Director = func(req *http.Request) {
+		if director != nil {
+			director(req)
+		}
+
+		service := pt.service
+		if pt.resolver != nil {
+			service = pt.resolver(req.URL)
+		}
+
+		var span *tracer.Span
+		if parent, ok := tracer.SpanFromContext(req.Context()); ok {
+			span = pt.tracer.NewChildSpan("http.proxy", parent)
+		} else {
+			span = pt.tracer.NewRootSpan("http.proxy", service, req.URL.Path)
+		}
+		span.Service = service
+		span.Resource = req.URL.Path
+		span.SetMeta("out.host", req.URL.Host)
+		span.SetMeta("http.method", req.Method)
+		span.SetMeta("http.url", req.URL.String())
+
+		state := &proxyState{span: span}
+		*req = *req.WithContext(context.WithValue(req.Context(), proxyStateKey{}, state))
+
+		if pt.propagator != nil {
+			sc := &tracer.SpanContext{TraceID: span.TraceID, SpanID: span.SpanID, Sampled: true}
+			pt.propagator.Inject(sc, tracer.TextMapCarrier(req.Header))
+		}
+	}
+
+	proxy.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if state, ok := req.Context().Value(proxyStateKey{}).(*proxyState); ok {
+			atomic.AddInt32(&state.attempts, 1)
+		}
+		return transport.RoundTrip(req)
+	})
+
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		if state, ok := stateFromRequest(resp.Request); ok {
+			tagOutcome(state, resp.StatusCode, nil)
+		}
+		if modifyResponse != nil {
+			return modifyResponse(resp)
+		}
+		return nil
+	}
+
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		if state, ok := stateFromRequest(req); ok {
+			tagOutcome(state, 0, err)
+		}
+		if errorHandler != nil {
+			errorHandler(w, req, err)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	return proxy
+}
+
+// tagOutcome records the backend's response status (or the error that
+// prevented one), the retry count, and finishes the span. It runs at
+// most once per request: ReverseProxy calls ErrorHandler both for a
+// failed round trip and when a user-supplied ModifyResponse itself
+// returns an error, and the second call must not re-tag or re-finish a
+// span tagOutcome already closed out.
+func tagOutcome(state *proxyState, statusCode int, err error) {
+	state.finishOnce.Do(func() {
+		span := state.span
+		if retries := int(atomic.LoadInt32(&state.attempts)) - 1; retries > 0 {
+			span.SetMeta("http.retries", strconv.Itoa(retries))
+		}
+		switch {
+		case err != nil:
+			span.SetError(err)
+		case statusCode != 0:
+			span.SetMeta("http.status_code", strconv.Itoa(statusCode))
+			if statusCode/100 == 5 {
+				span.SetError(fmt.Errorf("%d: %s", statusCode, http.StatusText(statusCode)))
+			}
+		}
+		span.Finish()
+	})
+}
+
+func stateFromRequest(req *http.Request) (*proxyState, bool) {
+	state, ok := req.Context().Value(proxyStateKey{}).(*proxyState)
+	return state, ok
+}
+
+// roundTripperFunc adapts a function to the http.RoundTripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
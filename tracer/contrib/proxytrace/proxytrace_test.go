@@ -0,0 +1,101 @@
+package proxytrace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"github.com/DataDog/dd-trace-go/tracer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyTracer200(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("backend!"))
+	}))
+	defer backend.Close()
+	backendURL, err := url.Parse(backend.URL)
+	assert.NoError(err)
+
+	trc, transport := getTestTracer()
+	pt := NewProxyTracer("my-proxy", trc)
+	proxy := pt.Trace(httputil.NewSingleHostReverseProxy(backendURL))
+
+	req := httptest.NewRequest("GET", "/hello", nil)
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, req)
+	assert.Equal(rec.Code, 200)
+	assert.Equal(rec.Body.String(), "backend!")
+
+	assert.Nil(trc.FlushTraces())
+	traces := transport.Traces()
+	assert.Len(traces, 1)
+	spans := traces[0]
+	assert.Len(spans, 1)
+
+	s := spans[0]
+	assert.Equal(s.Name, "http.proxy")
+	assert.Equal(s.Service, "my-proxy")
+	assert.Equal(s.Resource, "/hello")
+	assert.Equal(s.GetMeta("out.host"), backendURL.Host)
+	assert.Equal(s.GetMeta("http.status_code"), "200")
+	assert.Equal(s.Error, int32(0))
+}
+
+func TestProxyTracerBackendDown(t *testing.T) {
+	assert := assert.New(t)
+
+	// A URL nothing is listening on, so the proxy's RoundTrip fails.
+	backendURL, err := url.Parse("http://127.0.0.1:1")
+	assert.NoError(err)
+
+	trc, transport := getTestTracer()
+	pt := NewProxyTracer("my-proxy", trc)
+	proxy := pt.Trace(httputil.NewSingleHostReverseProxy(backendURL))
+
+	req := httptest.NewRequest("GET", "/hello", nil)
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, req)
+	assert.Equal(rec.Code, http.StatusBadGateway)
+
+	assert.Nil(trc.FlushTraces())
+	traces := transport.Traces()
+	assert.Len(traces, 1)
+	spans := traces[0]
+	assert.Len(spans, 1)
+	assert.Equal(spans[0].Error, int32(1))
+}
+
+// getTestTracer returns a Tracer with a DummyTransport.
+func getTestTracer() (*tracer.Tracer, *dummyTransport) {
+	transport := &dummyTransport{}
+	return tracer.NewTracerTransport(transport), transport
+}
+
+// dummyTransport is a transport that just buffers spans.
+type dummyTransport struct {
+	traces   [][]*tracer.Span
+	services map[string]tracer.Service
+}
+
+func (t *dummyTransport) SendTraces(traces [][]*tracer.Span) (*http.Response, error) {
+	t.traces = append(t.traces, traces...)
+	return nil, nil
+}
+
+func (t *dummyTransport) SendServices(services map[string]tracer.Service) (*http.Response, error) {
+	t.services = services
+	return nil, nil
+}
+
+func (t *dummyTransport) Traces() [][]*tracer.Span {
+	traces := t.traces
+	t.traces = nil
+	return traces
+}
+
+func (t *dummyTransport) SetHeader(key, value string) {}
@@ -0,0 +1,31 @@
+package sqltraced
+
+import "sync"
+
+// DSNParser extracts span meta tags (host, port, db name, user, ...) from
+// a driver-specific connection string.
+type DSNParser func(dsn string) (map[string]string, error)
+
+var (
+	parsersMu  sync.Mutex
+	dsnParsers = map[string]DSNParser{}
+)
+
+// RegisterDSNParser registers parser as the tag extractor used for DSNs
+// passed to driverName. Drivers this package doesn't understand out of
+// the box (MSSQL, ClickHouse, Snowflake, Vertica, ...) need one of these
+// before Register/OpenTraced is called so their spans still get host,
+// port, db name and user meta. Registering a parser for "mysql" or
+// "postgres" overrides the built-in one.
+func RegisterDSNParser(driverName string, parser DSNParser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	dsnParsers[driverName] = parser
+}
+
+func registeredDSNParser(driverName string) (DSNParser, bool) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	parser, ok := dsnParsers[driverName]
+	return parser, ok
+}
@@ -0,0 +1,49 @@
+package sqltraced
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterDSNParser(t *testing.T) {
+	assert := assert.New(t)
+
+	RegisterDSNParser("clickhouse", func(dsn string) (map[string]string, error) {
+		return map[string]string{"out.host": "chhost", "out.port": "9000", "db.name": "default"}, nil
+	})
+
+	meta := parseDSN("clickhouse", "tcp://chhost:9000?database=default")
+	assert.Equal("chhost", meta["out.host"])
+	assert.Equal("9000", meta["out.port"])
+	assert.Equal("default", meta["db.name"])
+}
+
+func TestParseDSNSSHTunnel(t *testing.T) {
+	assert := assert.New(t)
+
+	meta := parseDSN("mysql", "ubuntu@ssh(jumphost/10.0.0.5:3306)/circle_test")
+	assert.Equal("ssh", meta["db.tunnel"])
+	assert.Equal("jumphost", meta["db.tunnel_host"])
+	assert.Equal("10.0.0.5", meta["out.host"])
+	assert.Equal("3306", meta["out.port"])
+}
+
+func TestParseDSNUnixSocket(t *testing.T) {
+	assert := assert.New(t)
+
+	meta := parseDSN("mysql", "ubuntu@unix(/var/run/mysqld/mysqld.sock)/circle_test")
+	assert.Equal("/var/run/mysqld/mysqld.sock", meta["db.tunnel_host"])
+	assert.Equal("", meta["db.tunnel"])
+	assert.Equal("", meta["out.host"])
+}
+
+func TestParseDSNTLSTunnel(t *testing.T) {
+	assert := assert.New(t)
+
+	meta := parseDSN("mysql", "ubuntu@unix(/var/run/mysqld/mysqld.sock)/circle_test?tls-server-name=prod-db.internal:3306")
+	assert.Equal("tls", meta["db.tunnel"])
+	assert.Equal("/var/run/mysqld/mysqld.sock", meta["db.tunnel_host"])
+	assert.Equal("prod-db.internal", meta["out.host"])
+	assert.Equal("3306", meta["out.port"])
+}
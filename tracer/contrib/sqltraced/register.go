@@ -0,0 +1,50 @@
+package sqltraced
+
+import (
+	"database/sql"
+	gosqldriver "database/sql/driver"
+	"fmt"
+	"sync"
+
+	"github.com/DataDog/dd-trace-go/tracer"
+)
+
+var (
+	mu               sync.Mutex
+	registeredDriver = map[string]bool{}
+)
+
+// Register makes a traced version of driver available to database/sql
+// under driverName + "-traced", so that OpenTraced (and sqlxtraced's
+// OpenTraced) can sql.Open it. It is safe to call Register for the same
+// driverName more than once.
+func Register(driverName string, driver gosqldriver.Driver, service string, trc *tracer.Tracer) string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	tracedName := driverName + "-traced"
+	if !registeredDriver[tracedName] {
+		sql.Register(tracedName, &tracedDriver{
+			Driver: driver,
+			traceParams: traceParams{
+				tracer:     trc,
+				driverName: driverName,
+				service:    service,
+			},
+		})
+		registeredDriver[tracedName] = true
+	}
+	return tracedName
+}
+
+// OpenTraced registers a traced version of driver if needed and opens a
+// *sql.DB through it, so every query made against the returned DB is
+// reported to trc under service.
+func OpenTraced(driver gosqldriver.Driver, dsn, service string, trc *tracer.Tracer) (*sql.DB, error) {
+	driverName := GetDriverName(driver)
+	if driverName == "" {
+		return nil, fmt.Errorf("sqltraced: unsupported driver %T", driver)
+	}
+	tracedName := Register(driverName, driver, service, trc)
+	return sql.Open(tracedName, dsn)
+}
@@ -0,0 +1,148 @@
+// Package sqltraced provides tracing for the database/sql package. It
+// works by wrapping a database/sql/driver.Driver so every call it
+// receives is wrapped in a span with the driver, DSN and caller's
+// context taken into account.
+package sqltraced
+
+import (
+	"context"
+	gosqldriver "database/sql/driver"
+	"fmt"
+
+	"github.com/DataDog/dd-trace-go/tracer"
+)
+
+// traceParams carries everything a traced driver needs to build a span
+// for a call: the tracer to submit it to, the service/span name to use,
+// and the static meta tags derived from the DSN.
+type traceParams struct {
+	tracer     *tracer.Tracer
+	driverName string
+	service    string
+	meta       map[string]string
+}
+
+// newChildSpan starts a span for a database call made with ctx,
+// nesting it under whatever span ctx already carries (for example, the
+// mux.request span of the HTTP handler that triggered the query).
+func (tp *traceParams) newChildSpan(ctx context.Context, resource string) *tracer.Span {
+	name := tp.driverName + ".query"
+	var span *tracer.Span
+	if parent, ok := tracer.SpanFromContext(ctx); ok {
+		span = tp.tracer.NewChildSpan(name, parent)
+	} else {
+		span = tp.tracer.NewRootSpan(name, tp.service, resource)
+	}
+	span.Service = tp.service
+	span.Resource = resource
+	span.Type = "sql"
+	for k, v := range tp.meta {
+		span.SetMeta(k, v)
+	}
+	return span
+}
+
+// tracedDriver wraps a driver.Driver so every connection it opens is
+// wrapped in a tracedConn.
+type tracedDriver struct {
+	gosqldriver.Driver
+	traceParams
+}
+
+// Open opens a connection, parsing the DSN once to extract span meta.
+func (d *tracedDriver) Open(dsn string) (gosqldriver.Conn, error) {
+	conn, err := d.Driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	tp := d.traceParams
+	tp.meta = parseDSN(d.driverName, dsn)
+	return &tracedConn{conn, tp}, nil
+}
+
+// tracedConn wraps a driver.Conn so every statement it prepares is
+// wrapped in a tracedStmt.
+type tracedConn struct {
+	gosqldriver.Conn
+	traceParams
+}
+
+// Prepare wraps the prepared statement so Exec/Query on it produce spans.
+func (c *tracedConn) Prepare(query string) (gosqldriver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, traceParams: c.traceParams, query: query}, nil
+}
+
+// tracedStmt wraps a driver.Stmt so each execution emits a span nested
+// under the caller's context.
+type tracedStmt struct {
+	gosqldriver.Stmt
+	traceParams
+	query string
+}
+
+// ExecContext runs the statement inside a span nested under ctx. Drivers
+// that only implement the legacy driver.Stmt.Exec are supported too,
+// the same way database/sql itself falls back when a driver predates
+// context support.
+func (s *tracedStmt) ExecContext(ctx context.Context, args []gosqldriver.NamedValue) (gosqldriver.Result, error) {
+	span := s.newChildSpan(ctx, s.query)
+	defer span.Finish()
+
+	var res gosqldriver.Result
+	var err error
+	if execer, ok := s.Stmt.(gosqldriver.StmtExecContext); ok {
+		res, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []gosqldriver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			res, err = s.Stmt.Exec(values)
+		}
+	}
+	if err != nil {
+		span.SetError(err)
+	}
+	return res, err
+}
+
+// QueryContext runs the statement inside a span nested under ctx. Drivers
+// that only implement the legacy driver.Stmt.Query are supported too, the
+// same way database/sql itself falls back when a driver predates context
+// support.
+func (s *tracedStmt) QueryContext(ctx context.Context, args []gosqldriver.NamedValue) (gosqldriver.Rows, error) {
+	span := s.newChildSpan(ctx, s.query)
+	defer span.Finish()
+
+	var rows gosqldriver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(gosqldriver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []gosqldriver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			rows, err = s.Stmt.Query(values)
+		}
+	}
+	if err != nil {
+		span.SetError(err)
+	}
+	return rows, err
+}
+
+// namedValuesToValues converts the context-aware driver.NamedValue
+// arguments database/sql always builds into the plain driver.Value slice
+// the legacy, non-context Stmt.Exec/Stmt.Query expect. It rejects named
+// parameters, which those legacy methods have no way to express.
+func namedValuesToValues(named []gosqldriver.NamedValue) ([]gosqldriver.Value, error) {
+	values := make([]gosqldriver.Value, len(named))
+	for _, nv := range named {
+		if nv.Name != "" {
+			return nil, fmt.Errorf("sqltraced: driver statement does not support named parameters")
+		}
+		values[nv.Ordinal-1] = nv.Value
+	}
+	return values, nil
+}
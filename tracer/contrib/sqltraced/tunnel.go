@@ -0,0 +1,56 @@
+package sqltraced
+
+import "strings"
+
+// applyTunnelTags rewrites meta in place when the address a DSN parser
+// extracted into out.host is a local tunnel endpoint rather than the
+// database's own network address, so out.host/out.port keep describing
+// the real upstream and a db.tunnel tag records how it's reached:
+//
+//   - "ssh://jumphost/realhost:port" - an SSH-forwarded connection. The
+//     jump host is kept in db.tunnel_host, out.host/out.port become the
+//     real upstream, and db.tunnel is set to "ssh".
+//   - "unix:/path/to.sock" - a local Unix socket. db.tunnel_host keeps the
+//     socket path; if a db.tls_upstream tag is also present (a
+//     tls-server-name DSN parameter naming the host a TLS-terminating
+//     proxy ultimately reaches), it becomes out.host/out.port and
+//     db.tunnel is set to "tls". Otherwise there is no known remote
+//     upstream and out.host/out.port are left unset.
+func applyTunnelTags(meta map[string]string) {
+	switch host := meta["out.host"]; {
+	case strings.HasPrefix(host, "ssh://"):
+		rest := strings.TrimPrefix(host, "ssh://")
+		jumphost, upstream, _ := cut(rest, "/")
+		meta["db.tunnel"] = "ssh"
+		meta["db.tunnel_host"] = jumphost
+		delete(meta, "out.host")
+		delete(meta, "out.port")
+		if upstream != "" {
+			if h, p, err := splitHostPort(upstream); err == nil {
+				meta["out.host"] = h
+				meta["out.port"] = p
+			}
+		}
+	case strings.HasPrefix(host, "unix:"):
+		meta["db.tunnel_host"] = strings.TrimPrefix(host, "unix:")
+		delete(meta, "out.host")
+		delete(meta, "out.port")
+		if upstream, ok := meta["db.tls_upstream"]; ok {
+			meta["db.tunnel"] = "tls"
+			if h, p, err := splitHostPort(upstream); err == nil {
+				meta["out.host"] = h
+				meta["out.port"] = p
+			}
+		}
+	}
+	delete(meta, "db.tls_upstream")
+}
+
+// cut splits s on the first occurrence of sep, like strings.Cut (added in
+// Go 1.18, which this module predates).
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,29 @@
+// Package sqlxtraced provides tracing for github.com/jmoiron/sqlx on top
+// of the database/sql instrumentation in sqltraced.
+package sqlxtraced
+
+import (
+	gosqldriver "database/sql/driver"
+	"fmt"
+
+	"github.com/DataDog/dd-trace-go/tracer"
+	"github.com/DataDog/dd-trace-go/tracer/contrib/sqltraced"
+	"github.com/jmoiron/sqlx"
+)
+
+// OpenTraced opens a traced *sqlx.DB the same way sqltraced.OpenTraced
+// opens a traced *sql.DB, so every query made through it is reported to
+// trc under service.
+func OpenTraced(driver gosqldriver.Driver, dsn, service string, trc *tracer.Tracer) (*sqlx.DB, error) {
+	driverName := sqltraced.GetDriverName(driver)
+	if driverName == "" {
+		return nil, fmt.Errorf("sqltraced: unsupported driver %T", driver)
+	}
+	tracedName := sqltraced.Register(driverName, driver, service, trc)
+
+	db, err := sqlx.Open(tracedName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,83 @@
+package tracer
+
+import "net/http"
+
+// SpanContext carries the minimal distributed tracing state needed to
+// continue a trace that started in another process: the trace and parent
+// span identifiers, plus the sampling decision made upstream.
+type SpanContext struct {
+	TraceID  uint64
+	SpanID   uint64
+	Sampled  bool
+	Priority int
+}
+
+// TextMapCarrier adapts an http.Header (or any map of string to string
+// slices) so it can be used as the carrier argument of a Propagator. Wrap
+// a request or response header with TextMapCarrier(req.Header) before
+// passing it to Inject/Extract.
+type TextMapCarrier map[string][]string
+
+// Get returns the first value associated with key, if any.
+func (c TextMapCarrier) Get(key string) string {
+	if v, ok := c[http.CanonicalHeaderKey(key)]; ok && len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Set replaces any existing values for key with value.
+func (c TextMapCarrier) Set(key, value string) {
+	c[http.CanonicalHeaderKey(key)] = []string{value}
+}
+
+// Propagator injects a SpanContext into, and extracts one out of, a
+// carrier (typically HTTP headers) so a trace can cross process
+// boundaries. Implementations must tolerate a carrier with no tracing
+// headers by returning (nil, nil) from Extract rather than an error.
+type Propagator interface {
+	// Inject writes sc into carrier.
+	Inject(sc *SpanContext, carrier interface{}) error
+	// Extract reads a SpanContext out of carrier, or returns (nil, nil)
+	// if carrier holds no recognizable tracing headers.
+	Extract(carrier interface{}) (*SpanContext, error)
+}
+
+// ChainedPropagator extracts using the first propagator in the chain that
+// finds a SpanContext, and injects using every propagator in the chain so
+// downstream services can pick whichever format they understand.
+type ChainedPropagator struct {
+	propagators []Propagator
+}
+
+// NewChainedPropagator returns a Propagator that tries each of
+// propagators in order when extracting, and writes all of the supplied
+// formats when injecting.
+func NewChainedPropagator(propagators ...Propagator) *ChainedPropagator {
+	return &ChainedPropagator{propagators: propagators}
+}
+
+// Inject writes sc using every propagator in the chain.
+func (c *ChainedPropagator) Inject(sc *SpanContext, carrier interface{}) error {
+	for _, p := range c.propagators {
+		if err := p.Inject(sc, carrier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Extract returns the SpanContext produced by the first propagator in the
+// chain that recognizes the carrier's headers.
+func (c *ChainedPropagator) Extract(carrier interface{}) (*SpanContext, error) {
+	for _, p := range c.propagators {
+		sc, err := p.Extract(carrier)
+		if err != nil {
+			return nil, err
+		}
+		if sc != nil {
+			return sc, nil
+		}
+	}
+	return nil, nil
+}
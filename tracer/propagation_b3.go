@@ -0,0 +1,130 @@
+package tracer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// B3 multi-header field names, as defined by the OpenZipkin B3
+// propagation spec.
+const (
+	b3TraceIDHeader      = "X-B3-Traceid"
+	b3SpanIDHeader       = "X-B3-Spanid"
+	b3ParentSpanIDHeader = "X-B3-Parentspanid"
+	b3SampledHeader      = "X-B3-Sampled"
+	b3SingleHeader       = "B3"
+)
+
+// B3Propagator injects and extracts trace context using the B3
+// multi-header format (X-B3-TraceId, X-B3-SpanId, X-B3-Sampled, ...).
+type B3Propagator struct{}
+
+// NewB3Propagator returns a Propagator for the B3 multi-header format.
+func NewB3Propagator() *B3Propagator {
+	return &B3Propagator{}
+}
+
+// Inject writes sc as X-B3-* headers on carrier.
+func (*B3Propagator) Inject(sc *SpanContext, carrier interface{}) error {
+	c, ok := carrier.(TextMapCarrier)
+	if !ok {
+		return fmt.Errorf("tracer: B3Propagator requires a TextMapCarrier, got %T", carrier)
+	}
+	c.Set(b3TraceIDHeader, strconv.FormatUint(sc.TraceID, 16))
+	c.Set(b3SpanIDHeader, strconv.FormatUint(sc.SpanID, 16))
+	if sc.Sampled {
+		c.Set(b3SampledHeader, "1")
+	} else {
+		c.Set(b3SampledHeader, "0")
+	}
+	return nil
+}
+
+// Extract reads X-B3-* headers off carrier. It returns (nil, nil) if no
+// B3 trace ID header is present.
+func (*B3Propagator) Extract(carrier interface{}) (*SpanContext, error) {
+	c, ok := carrier.(TextMapCarrier)
+	if !ok {
+		return nil, fmt.Errorf("tracer: B3Propagator requires a TextMapCarrier, got %T", carrier)
+	}
+	traceID := c.Get(b3TraceIDHeader)
+	if traceID == "" {
+		return nil, nil
+	}
+	tid, err := strconv.ParseUint(traceID, 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("tracer: invalid %s header: %v", b3TraceIDHeader, err)
+	}
+	var sid uint64
+	if raw := c.Get(b3SpanIDHeader); raw != "" {
+		if sid, err = strconv.ParseUint(raw, 16, 64); err != nil {
+			return nil, fmt.Errorf("tracer: invalid %s header: %v", b3SpanIDHeader, err)
+		}
+	}
+	sc := &SpanContext{TraceID: tid, SpanID: sid}
+	if sampled := c.Get(b3SampledHeader); sampled != "" {
+		sc.Sampled = sampled == "1" || strings.EqualFold(sampled, "true")
+		if sc.Sampled {
+			sc.Priority = 1
+		}
+	}
+	return sc, nil
+}
+
+// B3SingleHeaderPropagator injects and extracts trace context using the
+// single-header B3 format: "b3: {traceid}-{spanid}-{sampled}-{parentspanid}".
+type B3SingleHeaderPropagator struct{}
+
+// NewB3SingleHeaderPropagator returns a Propagator for the single-header
+// B3 format.
+func NewB3SingleHeaderPropagator() *B3SingleHeaderPropagator {
+	return &B3SingleHeaderPropagator{}
+}
+
+// Inject writes sc as a single "B3" header on carrier.
+func (*B3SingleHeaderPropagator) Inject(sc *SpanContext, carrier interface{}) error {
+	c, ok := carrier.(TextMapCarrier)
+	if !ok {
+		return fmt.Errorf("tracer: B3SingleHeaderPropagator requires a TextMapCarrier, got %T", carrier)
+	}
+	sampled := "0"
+	if sc.Sampled {
+		sampled = "1"
+	}
+	c.Set(b3SingleHeader, fmt.Sprintf("%x-%x-%s", sc.TraceID, sc.SpanID, sampled))
+	return nil
+}
+
+// Extract reads the single "B3" header off carrier. It returns (nil, nil)
+// if the header is absent.
+func (*B3SingleHeaderPropagator) Extract(carrier interface{}) (*SpanContext, error) {
+	c, ok := carrier.(TextMapCarrier)
+	if !ok {
+		return nil, fmt.Errorf("tracer: B3SingleHeaderPropagator requires a TextMapCarrier, got %T", carrier)
+	}
+	header := c.Get(b3SingleHeader)
+	if header == "" {
+		return nil, nil
+	}
+	parts := strings.Split(header, "-")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("tracer: malformed %s header: %q", b3SingleHeader, header)
+	}
+	tid, err := strconv.ParseUint(parts[0], 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("tracer: invalid trace id in %s header: %v", b3SingleHeader, err)
+	}
+	sid, err := strconv.ParseUint(parts[1], 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("tracer: invalid span id in %s header: %v", b3SingleHeader, err)
+	}
+	sc := &SpanContext{TraceID: tid, SpanID: sid}
+	if len(parts) >= 3 {
+		sc.Sampled = parts[2] == "1" || strings.EqualFold(parts[2], "d")
+		if sc.Sampled {
+			sc.Priority = 1
+		}
+	}
+	return sc, nil
+}
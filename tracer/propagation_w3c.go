@@ -0,0 +1,82 @@
+package tracer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// W3C trace context header names, as defined by the W3C Trace Context
+// recommendation.
+const (
+	traceparentHeader = "Traceparent"
+	tracestateHeader  = "Tracestate"
+	w3cVersion        = "00"
+)
+
+// W3CPropagator injects and extracts trace context using the W3C
+// traceparent header. Only the trace and parent span identifiers and the
+// sampled flag are round-tripped; the tracestate header is neither read
+// by Extract nor written by Inject, so it is not carried from an
+// extracted context into an injected one.
+type W3CPropagator struct{}
+
+// NewW3CPropagator returns a Propagator for the W3C traceparent format.
+func NewW3CPropagator() *W3CPropagator {
+	return &W3CPropagator{}
+}
+
+// Inject writes sc as a "traceparent" header. A tracestate header already
+// present on the carrier is left as it is.
+func (*W3CPropagator) Inject(sc *SpanContext, carrier interface{}) error {
+	c, ok := carrier.(TextMapCarrier)
+	if !ok {
+		return fmt.Errorf("tracer: W3CPropagator requires a TextMapCarrier, got %T", carrier)
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	c.Set(traceparentHeader, fmt.Sprintf("%s-%032x-%016x-%s", w3cVersion, sc.TraceID, sc.SpanID, flags))
+	return nil
+}
+
+// Extract reads the "traceparent" header off carrier. It returns (nil,
+// nil) if the header is absent or uses a version this tracer does not
+// understand.
+func (*W3CPropagator) Extract(carrier interface{}) (*SpanContext, error) {
+	c, ok := carrier.(TextMapCarrier)
+	if !ok {
+		return nil, fmt.Errorf("tracer: W3CPropagator requires a TextMapCarrier, got %T", carrier)
+	}
+	header := c.Get(traceparentHeader)
+	if header == "" {
+		return nil, nil
+	}
+	parts := strings.Split(header, "-")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("tracer: malformed %s header: %q", traceparentHeader, header)
+	}
+	if parts[0] != w3cVersion {
+		// An unknown version might add fields we don't understand; rather
+		// than guess, decline to extract so a fresh trace is started.
+		return nil, nil
+	}
+	tid, err := strconv.ParseUint(parts[1][len(parts[1])-16:], 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("tracer: invalid trace id in %s header: %v", traceparentHeader, err)
+	}
+	sid, err := strconv.ParseUint(parts[2], 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("tracer: invalid parent id in %s header: %v", traceparentHeader, err)
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return nil, fmt.Errorf("tracer: invalid flags in %s header: %v", traceparentHeader, err)
+	}
+	sc := &SpanContext{TraceID: tid, SpanID: sid, Sampled: flags&0x1 == 1}
+	if sc.Sampled {
+		sc.Priority = 1
+	}
+	return sc, nil
+}